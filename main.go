@@ -1,8 +1,11 @@
 package main
 
 import (
+	"encoding/xml"
+	"fmt"
+	"hash/crc32"
+	"html/template"
 	"io/ioutil"
-	"math/rand"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -10,8 +13,25 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"gopkg.in/yaml.v3"
+
+	"github.com/dianso/notepad-go/middleware"
+	"github.com/dianso/notepad-go/notes"
+	"github.com/dianso/notepad-go/render"
+	"github.com/dianso/notepad-go/shortid"
 )
 
+// defaultMimeType 是笔记没有显式记录 mime type 时，/:path/raw 使用的兜底值
+const defaultMimeType = "text/plain; charset=utf-8"
+
+// unlockCookieName 是 POST /:path/unlock 成功后下发的 cookie 名。浏览器按
+// (name, path) 区分 cookie，所以把 cookie 的 Path 设成具体笔记路径，解锁
+// A 不会覆盖 B 的解锁状态
+const unlockCookieName = "note_unlock"
+
+// noteMetaContextKey 是 noteAuth 中间件把已经读过的 sidecar meta 存进
+// gin.Context 用的 key，后续 handler 可以直接取，不用再读一遍磁盘
+const noteMetaContextKey = "noteMeta"
+
 // Config 定义了与 config.yml 文件结构相对应的结构体
 type Config struct {
 	Server struct {
@@ -21,13 +41,36 @@ type Config struct {
 		TmpPath string `yaml:"tmp_path"` // 文件存储临时路径
 	} `yaml:"storage"`
 	Random struct {
-		StringLength int `yaml:"string_length"` // 随机字符串长度
+		Alphabet         string `yaml:"alphabet"`          // "base58" | "base62" | "nolookalike"，默认 base62
+		Length           int    `yaml:"length"`            // 生成的短 ID 长度
+		CollisionRetries int    `yaml:"collision_retries"` // 撞库后最多重试几次
 	} `yaml:"random"`
+	Compression middleware.GzipConfig `yaml:"compression"` // 响应压缩配置
+	Auth        struct {
+		Tokens       []string `yaml:"tokens"`        // 允许写操作的 token 列表，为空则不鉴权
+		CookieSecret string   `yaml:"cookie_secret"` // 签发 unlock cookie 用的 HMAC 密钥
+	} `yaml:"auth"`
+}
+
+// pageView 是 "/:path" 页面在 JSON/XML 协商模式下的返回形态
+type pageView struct {
+	XMLName xml.Name `xml:"page" json:"-"`
+	Title   string   `xml:"title" json:"title"`
+	Body    string   `xml:"body" json:"body"`
+}
+
+// noteView 是 "/api/v1/notes/:path" GET 在 JSON/XML 协商模式下的返回形态
+type noteView struct {
+	XMLName   xml.Name  `xml:"note" json:"-"`
+	Content   string    `xml:"content" json:"content"`
+	CreatedAt time.Time `xml:"created_at" json:"created_at"`
+	UpdatedAt time.Time `xml:"updated_at" json:"updated_at"`
+	Size      int64     `xml:"size" json:"size"`
 }
 
 func main() {
 	gin.SetMode(gin.ReleaseMode) // 设置 Gin 框架为发布模式
-	r := gin.Default()           // 创建默认的 Gin 路由器
+	r := gin.New()               // 用 gin.New() 而不是 Default()，中间件栈自己组装
 
 	// 从 config.yml 文件加载配置
 	config, err := loadConfig("config.yml")
@@ -35,57 +78,395 @@ func main() {
 		panic(err) // 如果配置文件加载失败，则终止程序
 	}
 
-	// 设置静态资源目录和 HTML 模板
-	r.Static("/static", "./static")
-	r.LoadHTMLFiles("index.html")
+	// 中间件顺序有讲究：Recovery 必须包住其它所有中间件才能兜住 panic；
+	// AccessLog 要包在 Gzip 外层，这样它读到的响应字节数才是压缩后落盘的真实值
+	r.Use(
+		middleware.Recovery(middleware.StderrSink{Out: os.Stderr}),
+		middleware.RequestID(),
+		middleware.AccessLog(os.Stdout),
+		middleware.Gzip(config.Compression),
+	)
 
-	rand.Seed(time.Now().UnixNano()) // 初始化随机数生成器
+	// 设置静态资源目录和 HTML 模板。模板按 {{ define }} 的名字登记，
+	// layout.html 只贡献 header/footer 片段，具体页面（note/markdown/code/raw）
+	// 各自定义一个同名模板来拼装
+	r.Static("/static", "./static")
+	r.LoadHTMLGlob("templates/*.html")
 
 	setupRoutes(r, config) // 配置路由
 
 	r.Run(config.Server.Port) // 启动服务器并监听配置文件指定的端口
 }
 
-// setupRoutes 配置路由和处理函数
+// setupRoutes 配置路由和处理函数。浏览器渲染与机器可读的 JSON API 完全分离：
+// 前者只挂在 "/:path" 下负责展示，后者统一收敛到 "/api/v1/notes" 分组。
 func setupRoutes(r *gin.Engine, config Config) {
 	r.GET("/", func(c *gin.Context) {
-		randomString := generateRandomString(config.Random.StringLength) // 生成指定长度的随机字符串
-		c.Redirect(http.StatusFound, "/"+randomString)                   // 重定向到随机字符串对应的 URL
+		id, err := shortid.New(shortid.Config{
+			Alphabet:         config.Random.Alphabet,
+			Length:           config.Random.Length,
+			CollisionRetries: config.Random.CollisionRetries,
+			Dir:              config.Storage.TmpPath,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Redirect(http.StatusFound, "/"+id) // 重定向到新生成的笔记路径
 	})
 
-	r.GET("/:path", func(c *gin.Context) {
+	// 笔记解锁：校验密码后下发签名 cookie，后续同路径的请求凭 cookie 免密
+	r.POST("/:path/unlock", unlockHandler(config))
+
+	// 浏览器直达页面：只负责渲染，不再借机创建文件——创建是写接口的职责。
+	// 同时支持内容协商，方便 curl/脚本直接用 Accept 头或 ?format= 拿结构化数据。
+	// noteAuth 挡在前面，给设了密码的笔记要求同样的凭证
+	r.GET("/:path", noteAuth(config), func(c *gin.Context) {
 		path := c.Param("path")
-		filePath := filepath.Join(config.Storage.TmpPath, path) // 构造文件完整路径
-		if err := ensureFileExists(filePath); err != nil {
+		filePath := filepath.Join(config.Storage.TmpPath, path)
+
+		fileContent, err := os.ReadFile(filePath)
+		if os.IsNotExist(err) {
+			html, herr := renderView(resolveView(c, nil), path, "")
+			if herr != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": herr.Error()})
+				return
+			}
+			render.Negotiate(c, http.StatusNotFound, pageView{Title: path, Body: ""}, html)
+			return
+		}
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		fileContent, err := os.ReadFile(filePath) // 读取文件内容
+
+		meta, err := noteMeta(c, filePath)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		c.HTML(http.StatusOK, "index.html", gin.H{"title": path, "body": string(fileContent)}) // 使用 HTML 模板渲染并返回内容
+		html, herr := renderView(resolveView(c, meta), path, string(fileContent))
+		if herr != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": herr.Error()})
+			return
+		}
+		render.Negotiate(c, http.StatusOK, pageView{Title: path, Body: string(fileContent)}, html)
 	})
 
-	r.POST("/:path", func(c *gin.Context) {
-		body, err := c.GetRawData() // 获取请求体数据
+	// Raw 直链：跳过模板渲染，原样把文件字节按记录的 mime type 吐回去，
+	// 让笔记可以当成 pastebin 式的直接链接分享
+	r.GET("/:path/raw", noteAuth(config), func(c *gin.Context) {
+		filePath := filepath.Join(config.Storage.TmpPath, c.Param("path"))
+
+		content, err := os.ReadFile(filePath)
+		if os.IsNotExist(err) {
+			c.Status(http.StatusNotFound)
+			return
+		}
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error reading request body"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+
+		mimeType := defaultMimeType
+		if meta, err := noteMeta(c, filePath); err == nil && meta != nil && meta.MimeType != "" {
+			mimeType = meta.MimeType
+		}
+		c.Data(http.StatusOK, mimeType, content)
+	})
+
+	// /api/v1/notes 承载所有面向机器的 JSON 接口，遵循标准的 REST 动词语义。
+	// 写动词额外经过 Auth 中间件；GET 保持公开，分享出去的只读链接不受影响
+	api := r.Group("/api/v1")
+	{
+		notesAPI := api.Group("/notes")
+		notesAPI.Use(middleware.Auth(config.Auth.Tokens), noteAuth(config))
+		{
+			notesAPI.GET("/:path", getNoteHandler(config))
+			notesAPI.PUT("/:path", putNoteHandler(config))
+			notesAPI.PATCH("/:path", patchNoteHandler(config))
+			notesAPI.DELETE("/:path", deleteNoteHandler(config))
+		}
+	}
+}
+
+// noteAuth 对设了密码的笔记要求同样的凭证：未设密码的笔记直接放行。
+// 凭证可以是 `Authorization: Bearer <password>`，也可以是 unlock 接口
+// 下发的签名 cookie，两者选一即可通过
+func noteAuth(config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
 		path := c.Param("path")
 		filePath := filepath.Join(config.Storage.TmpPath, path)
+
+		meta, err := notes.LoadMeta(filePath)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Set(noteMetaContextKey, meta) // 供下游 handler 复用，避免重复读 sidecar
+
+		if !meta.HasPassword() || meta.Expired(time.Now()) {
+			c.Next()
+			return
+		}
+
+		if token := middleware.BearerToken(c); token != "" && meta.CheckPassword(token) {
+			c.Next()
+			return
+		}
+		if cookie, err := c.Cookie(unlockCookieName); err == nil && notes.VerifyUnlockCookie(config.Auth.CookieSecret, path, cookie) {
+			c.Next()
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "password required"})
+	}
+}
+
+// noteMeta 优先用 noteAuth 中间件已经存进 context 的 sidecar meta，没有的话
+// （比如调用方没有挂 noteAuth）才回退去读一遍磁盘
+func noteMeta(c *gin.Context, filePath string) (*notes.Meta, error) {
+	if v, ok := c.Get(noteMetaContextKey); ok {
+		return v.(*notes.Meta), nil
+	}
+	return notes.LoadMeta(filePath)
+}
+
+// unlockHandler 校验笔记密码，成功后下发一个签名 cookie，免去后续请求
+// 每次都带密码的麻烦
+func unlockHandler(config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.Param("path")
+		filePath := filepath.Join(config.Storage.TmpPath, path)
+
+		meta, err := notes.LoadMeta(filePath)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !meta.HasPassword() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "note is not password protected"})
+			return
+		}
+
+		var body struct {
+			Password string `json:"password"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil || body.Password == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expected {password} body"})
+			return
+		}
+		if meta.Expired(time.Now()) || !meta.CheckPassword(body.Password) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid password"})
+			return
+		}
+
+		cookie := notes.SignUnlockCookie(config.Auth.CookieSecret, path)
+		c.SetCookie(unlockCookieName, cookie, 0, "/"+path, "", false, true)
+		c.JSON(http.StatusOK, gin.H{"status": "unlocked"})
+	}
+}
+
+// resolveView 决定 "/:path" 用哪个模板渲染：显式的 ?view= 优先，其次是
+// PUT 时通过 X-Note-Render 写进 sidecar 的默认值，都没有就交给 renderView
+// 落回 note.html
+func resolveView(c *gin.Context, meta *notes.Meta) string {
+	if view := c.Query("view"); view != "" {
+		return view
+	}
+	if meta != nil {
+		return meta.RenderMode
+	}
+	return ""
+}
+
+// renderView 按 view 选模板，并在需要时把笔记内容转换成该视图要求的形式
+// （markdown 转 HTML、代码转语法高亮 HTML）。转换结果必须转成 template.HTML，
+// 否则 html/template 会把生成的标签当成纯文本转义掉
+func renderView(view, title, body string) (render.HTML, error) {
+	switch view {
+	case "md":
+		rendered, err := render.Markdown(body)
+		if err != nil {
+			return render.HTML{}, err
+		}
+		return render.HTML{Name: "markdown.html", Data: gin.H{"title": title, "body": template.HTML(rendered)}}, nil
+	case "code":
+		rendered, err := render.Highlight(body, "")
+		if err != nil {
+			return render.HTML{}, err
+		}
+		return render.HTML{Name: "code.html", Data: gin.H{"title": title, "body": template.HTML(rendered)}}, nil
+	case "raw":
+		return render.HTML{Name: "raw.html", Data: gin.H{"title": title, "body": body}}, nil
+	default:
+		return render.HTML{Name: "note.html", Data: gin.H{"title": title, "body": body}}, nil
+	}
+}
+
+// getNoteHandler 返回笔记内容及其元信息
+func getNoteHandler(config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filePath := filepath.Join(config.Storage.TmpPath, c.Param("path"))
+
+		info, err := os.Stat(filePath)
+		if os.IsNotExist(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "note not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// created_at 来自 sidecar（sidecar 第一次写入时记录），没有 sidecar
+		// 的笔记（没设过密码/渲染模式）就只能退回到文件的 mtime
+		createdAt := info.ModTime()
+		if meta, err := noteMeta(c, filePath); err == nil && meta != nil {
+			createdAt = meta.CreatedAt
+		}
+
+		view := noteView{
+			Content:   string(content),
+			CreatedAt: createdAt,
+			UpdatedAt: info.ModTime(),
+			Size:      info.Size(),
+		}
+		render.Negotiate(c, http.StatusOK, view, render.HTML{
+			Name: "note.html",
+			Data: gin.H{"title": c.Param("path"), "body": view.Content},
+		})
+	}
+}
+
+// putNoteHandler 全量替换（或首次创建）笔记内容
+func putNoteHandler(config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := c.GetRawData()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error reading request body"})
+			return
+		}
+
+		filePath := filepath.Join(config.Storage.TmpPath, c.Param("path"))
+		_, statErr := os.Stat(filePath)
+		created := os.IsNotExist(statErr)
+
+		// X-Note-Password / X-Note-Render 按需覆盖已有 ACL 中的对应字段，
+		// 都不存在时 meta 留 nil，SaveAtomic 不会碰这篇笔记已有的 sidecar
+		existingMeta, err := noteMeta(c, filePath)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		meta := existingMeta
+		touched := false
+		if password := c.GetHeader("X-Note-Password"); password != "" {
+			meta, err = meta.WithPassword(password)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			touched = true
+		}
+		if renderMode := c.GetHeader("X-Note-Render"); renderMode != "" {
+			meta = meta.WithRenderMode(renderMode)
+			touched = true
+		}
+		if touched {
+			meta.MimeType = c.ContentType()
+		} else {
+			meta = nil
+		}
+
+		// 内容和密码元数据各自走"写临时文件 + rename"，不会出现内容已落盘
+		// 但密码哈希还没写完的中间状态
+		if err := notes.SaveAtomic(filePath, body, meta); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error writing to file"})
+			return
+		}
+
+		c.Header("ETag", etagFor(body))
+		status := http.StatusOK
+		if created {
+			status = http.StatusCreated
+		}
+		c.JSON(status, gin.H{"status": "Success"})
+	}
+}
+
+// patchNoteHandler 支持两种局部更新方式：裸文本直接追加，或 JSON body
+// {"op":"append","data":"..."} 形式的显式指令，为将来扩展更多 op 留出空间
+func patchNoteHandler(config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filePath := filepath.Join(config.Storage.TmpPath, c.Param("path"))
 		if err := ensureFileExists(filePath); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		if err := os.WriteFile(filePath, body, 0644); err != nil { // 将数据写入文件
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error writing to file"})
+
+		var patch struct {
+			Op   string `json:"op"`
+			Data string `json:"data"`
+		}
+		if c.ContentType() == "application/json" {
+			if err := c.ShouldBindJSON(&patch); err != nil || patch.Op == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "expected {op, data} body"})
+				return
+			}
+		} else {
+			// 非 JSON 的 body 视为裸文本，直接追加
+			raw, err := c.GetRawData()
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "error reading request body"})
+				return
+			}
+			patch.Op, patch.Data = "append", string(raw)
+		}
+
+		switch patch.Op {
+		case "append":
+			f, err := os.OpenFile(filePath, os.O_APPEND|os.O_WRONLY, 0644)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			defer f.Close()
+			if _, err := f.WriteString(patch.Data); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported op: " + patch.Op})
+			return
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("ETag", etagFor(content))
+		c.JSON(http.StatusOK, gin.H{"status": "Success"})
+	}
+}
+
+// deleteNoteHandler 删除笔记文件及其密码 sidecar（如果有）
+func deleteNoteHandler(config Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filePath := filepath.Join(config.Storage.TmpPath, c.Param("path"))
+		if err := notes.DeleteContentAndMeta(filePath); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"status": "Success"}) // 返回成功状态
-	})
+		c.Status(http.StatusNoContent)
+	}
 }
 
 // loadConfig 从指定路径加载配置文件并解析
@@ -99,17 +480,10 @@ func loadConfig(path string) (Config, error) {
 	return config, err
 }
 
-// generateRandomString 生成指定长度的随机字符串
-func generateRandomString(length int) string {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = charset[rand.Intn(len(charset))] // 从字符集中随机选取字符
-	}
-	return string(b)
-}
-
-// ensureFileExists 确保指定的文件存在，如果不存在则创建
+// ensureFileExists 确保指定的文件存在，如果不存在则创建。
+// 这是一个内部辅助函数，仅供 PATCH 调用（PUT 改走 notes.SaveAtomic，
+// 因为它可能要和密码 sidecar 一起原子落盘）；只读的浏览渲染不应该有
+// 创建文件的副作用。
 func ensureFileExists(filePath string) error {
 	dir := filepath.Dir(filePath) // 获取文件所在的目录
 	if err := os.MkdirAll(dir, 0755); err != nil { // 创建目录，如果不存在
@@ -121,3 +495,9 @@ func ensureFileExists(filePath string) error {
 	}
 	return err
 }
+
+// etagFor 用内容的 CRC32 校验和生成 ETag
+func etagFor(content []byte) string {
+	sum := crc32.ChecksumIEEE(content)
+	return fmt.Sprintf(`"%08x"`, sum)
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// accessLogEntry 是单条访问日志的 JSON 结构
+type accessLogEntry struct {
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"request_id"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Status    int       `json:"status"`
+	Bytes     int       `json:"bytes"`
+	LatencyMs int64     `json:"latency_ms"`
+}
+
+// AccessLog 记录每个请求的耗时、状态码、响应体大小，以 JSON 行的形式写
+// 入 out（生产环境通常是 os.Stdout，方便被日志采集系统按行解析）
+func AccessLog(out io.Writer) gin.HandlerFunc {
+	encoder := json.NewEncoder(out)
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		entry := accessLogEntry{
+			Time:      start,
+			RequestID: GetRequestID(c),
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.Path,
+			Status:    c.Writer.Status(),
+			Bytes:     c.Writer.Size(),
+			LatencyMs: time.Since(start).Milliseconds(),
+		}
+		_ = encoder.Encode(entry)
+	}
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeMethods 是需要鉴权的动词；只读的 GET 保持公开，分享出去的链接才能
+// 继续不带 token 访问
+var writeMethods = map[string]bool{
+	http.MethodPost:   true,
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+// Auth 对写请求要求 Bearer token 或 HTTP Basic（密码位放 token），token
+// 来自 config.yml 的 auth.tokens。tokens 为空时视为未启用鉴权
+func Auth(tokens []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if len(tokens) == 0 || !writeMethods[c.Request.Method] {
+			c.Next()
+			return
+		}
+
+		if !tokenMatches(BearerToken(c), tokens) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing or invalid token"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// BearerToken 支持 `Authorization: Bearer <token>` 和
+// `Authorization: Basic base64(user:token)` 两种形式，用户名部分被忽略。
+// 导出给 per-note 密码校验复用，不止服务于 Auth 中间件
+func BearerToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	if strings.HasPrefix(header, "Bearer ") {
+		return strings.TrimPrefix(header, "Bearer ")
+	}
+	if _, password, ok := c.Request.BasicAuth(); ok {
+		return password
+	}
+	return ""
+}
+
+func tokenMatches(candidate string, tokens []string) bool {
+	if candidate == "" {
+		return false
+	}
+	for _, t := range tokens {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(t)) == 1 {
+			return true
+		}
+	}
+	return false
+}
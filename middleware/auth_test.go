@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newAuthRouter(tokens []string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Auth(tokens))
+	r.GET("/note", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.POST("/note", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func TestAuthAllowsPublicReads(t *testing.T) {
+	r := newAuthRouter([]string{"secret"})
+	req := httptest.NewRequest(http.MethodGet, "/note", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected GET to stay public, got %d", w.Code)
+	}
+}
+
+func TestAuthRejectsWritesWithoutToken(t *testing.T) {
+	r := newAuthRouter([]string{"secret"})
+	req := httptest.NewRequest(http.MethodPost, "/note", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token, got %d", w.Code)
+	}
+}
+
+func TestAuthAcceptsBearerToken(t *testing.T) {
+	r := newAuthRouter([]string{"secret"})
+	req := httptest.NewRequest(http.MethodPost, "/note", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected bearer token to be accepted, got %d", w.Code)
+	}
+}
+
+func TestAuthDisabledWithoutConfiguredTokens(t *testing.T) {
+	r := newAuthRouter(nil)
+	req := httptest.NewRequest(http.MethodPost, "/note", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected writes to stay open when no tokens configured, got %d", w.Code)
+	}
+}
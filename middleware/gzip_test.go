@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newGzipRouter(cfg GzipConfig, body string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Gzip(cfg))
+	r.GET("/note", func(c *gin.Context) { c.String(http.StatusOK, body) })
+	return r
+}
+
+func TestGzipCompressesLargeResponses(t *testing.T) {
+	body := strings.Repeat("a", 2048)
+	r := newGzipRouter(GzipConfig{Enabled: true, Level: gzip.DefaultCompression, MinSize: 128}, body)
+
+	req := httptest.NewRequest(http.MethodGet, "/note", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected gzip encoding, got headers %v", w.Header())
+	}
+
+	gr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("response body is not valid gzip: %v", err)
+	}
+	defer gr.Close()
+	decoded, err := io.ReadAll(gr)
+	if err != nil {
+		t.Fatalf("failed to decode gzip body: %v", err)
+	}
+	if string(decoded) != body {
+		t.Fatalf("decoded body does not match original")
+	}
+}
+
+func TestGzipSkipsSmallResponses(t *testing.T) {
+	r := newGzipRouter(GzipConfig{Enabled: true, Level: gzip.DefaultCompression, MinSize: 1024}, "tiny")
+
+	req := httptest.NewRequest(http.MethodGet, "/note", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Header().Get("Content-Encoding") == "gzip" {
+		t.Fatalf("expected no compression for a body under min_size")
+	}
+	if w.Body.String() != "tiny" {
+		t.Fatalf("expected plain body, got %q", w.Body.String())
+	}
+}
+
+func TestGzipSkipsWhenDisabled(t *testing.T) {
+	r := newGzipRouter(GzipConfig{Enabled: false}, "hello")
+
+	req := httptest.NewRequest(http.MethodGet, "/note", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Header().Get("Content-Encoding") == "gzip" {
+		t.Fatalf("expected disabled config to skip compression")
+	}
+}
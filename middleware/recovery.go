@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PanicEntry 描述一次被恢复的 panic，交给 PanicSink 处理
+type PanicEntry struct {
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"request_id"`
+	Path      string    `json:"path"`
+	Error     string    `json:"error"`
+	Stack     string    `json:"stack"`
+}
+
+// PanicSink 接收 Recovery 中间件捕获到的 panic，实现可以是打日志、
+// 发往 Sentry、打到内部告警 webhook 等，业务方按需替换
+type PanicSink interface {
+	Write(entry PanicEntry)
+}
+
+// StderrSink 是默认的 PanicSink 实现，把 panic 以 JSON 行的形式写到给定的
+// io.Writer（通常是 os.Stderr）
+type StderrSink struct {
+	Out io.Writer
+}
+
+// Write 实现 PanicSink
+func (s StderrSink) Write(entry PanicEntry) {
+	_ = json.NewEncoder(s.Out).Encode(entry)
+}
+
+// Recovery 替代 gin.Recovery()：捕获 handler 里的 panic，把请求 id、路径、
+// 错误信息和调用栈交给 sink，再给客户端返回 500，而不是让连接直接断开
+func Recovery(sink PanicSink) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				err, ok := r.(error)
+				if !ok {
+					err = errorString{r}
+				}
+
+				sink.Write(PanicEntry{
+					Time:      time.Now(),
+					RequestID: GetRequestID(c),
+					Path:      c.Request.URL.Path,
+					Error:     err.Error(),
+					Stack:     string(debug.Stack()),
+				})
+
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+			}
+		}()
+		c.Next()
+	}
+}
+
+// errorString 把任意 recover() 到的值包成 error，避免 panic(42) 这类非
+// error 类型的 panic 让上面的类型断言失败
+type errorString struct{ v interface{} }
+
+func (e errorString) Error() string {
+	return fmt.Sprintf("panic: %v", e.v)
+}
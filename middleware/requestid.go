@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+)
+
+const requestIDKey = "request_id"
+const requestIDHeader = "X-Request-ID"
+
+// RequestID 沿用请求自带的 X-Request-ID，没有时生成一个随机 ID，写回 X-Request-ID 响应头，
+// 并存进 gin.Context 供访问日志、panic 恢复等后续中间件复用
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if id == "" {
+			id = newRequestID()
+		}
+		c.Set(requestIDKey, id)
+		c.Writer.Header().Set(requestIDHeader, id)
+		c.Next()
+	}
+}
+
+// GetRequestID 取出当前请求的 request id，未设置时返回空字符串
+func GetRequestID(c *gin.Context) string {
+	id, _ := c.Get(requestIDKey)
+	s, _ := id.(string)
+	return s
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
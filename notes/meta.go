@@ -0,0 +1,109 @@
+// Package notes 管理笔记内容旁边的 sidecar 元数据文件（<path>.meta.json），
+// 承载密码保护、渲染模式选择等每篇笔记级别的设置。
+package notes
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Meta 是 <path>.meta.json 的内容
+type Meta struct {
+	PasswordHash string     `json:"password_hash,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	MimeType     string     `json:"mime_type,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	RenderMode   string     `json:"render_mode,omitempty"` // GET /:path 默认用哪个模板：raw/md/code/html，空串回落到 note.html
+}
+
+// metaPath 返回内容文件对应的 sidecar 元数据文件路径
+func metaPath(contentPath string) string {
+	return contentPath + ".meta.json"
+}
+
+// LoadMeta 读取内容文件对应的 sidecar。文件不存在时返回 (nil, nil)，
+// 表示这篇笔记没有任何 ACL，调用方应当放行
+func LoadMeta(contentPath string) (*Meta, error) {
+	data, err := os.ReadFile(metaPath(contentPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var meta Meta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+// HasPassword 报告这篇笔记是否设置了密码
+func (m *Meta) HasPassword() bool {
+	return m != nil && m.PasswordHash != ""
+}
+
+// Expired 报告这篇笔记的密码保护是否已经过期
+func (m *Meta) Expired(now time.Time) bool {
+	return m != nil && m.ExpiresAt != nil && now.After(*m.ExpiresAt)
+}
+
+// CheckPassword 校验明文密码是否匹配存储的 bcrypt 哈希
+func (m *Meta) CheckPassword(password string) bool {
+	if !m.HasPassword() {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(m.PasswordHash), []byte(password)) == nil
+}
+
+// marshalMeta 序列化 Meta 供 SaveAtomic 落盘
+func marshalMeta(m *Meta) ([]byte, error) {
+	return json.Marshal(m)
+}
+
+// NewPasswordMeta 为一次带密码的 PUT 构造新的 Meta，负责把明文密码哈希掉
+func NewPasswordMeta(password, mimeType string, expiresAt *time.Time) (*Meta, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	return &Meta{
+		PasswordHash: string(hash),
+		CreatedAt:    time.Now(),
+		MimeType:     mimeType,
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+// WithPassword 在 m 的基础上返回一份设置了新密码的副本，m 为 nil（这篇笔记
+// 还没有任何 ACL）时新建。用于 PUT 请求要在同一次调用里更新密码和/或渲染
+// 模式，又不想互相覆盖对方字段的场景
+func (m *Meta) WithPassword(password string) (*Meta, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	next := m.clone()
+	next.PasswordHash = string(hash)
+	return next, nil
+}
+
+// WithRenderMode 在 m 的基础上返回一份设置了新渲染模式的副本，m 为 nil 时新建
+func (m *Meta) WithRenderMode(mode string) *Meta {
+	next := m.clone()
+	next.RenderMode = mode
+	return next
+}
+
+// clone 复制一份 Meta 供 With* 系列方法在不改动调用方持有的值的前提下修改
+func (m *Meta) clone() *Meta {
+	if m == nil {
+		return &Meta{CreatedAt: time.Now()}
+	}
+	next := *m
+	return &next
+}
@@ -0,0 +1,109 @@
+package notes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveAtomicWritesContentAndMeta(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "note")
+
+	meta, err := NewPasswordMeta("secret", "text/plain", nil)
+	if err != nil {
+		t.Fatalf("NewPasswordMeta: %v", err)
+	}
+	if err := SaveAtomic(path, []byte("hello"), meta); err != nil {
+		t.Fatalf("SaveAtomic: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil || string(content) != "hello" {
+		t.Fatalf("unexpected content %q, err %v", content, err)
+	}
+
+	loaded, err := LoadMeta(path)
+	if err != nil {
+		t.Fatalf("LoadMeta: %v", err)
+	}
+	if !loaded.CheckPassword("secret") {
+		t.Fatalf("expected stored hash to match the original password")
+	}
+}
+
+func TestSaveAtomicNilMetaLeavesExistingACLUntouched(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "note")
+
+	meta, _ := NewPasswordMeta("secret", "text/plain", nil)
+	if err := SaveAtomic(path, []byte("v1"), meta); err != nil {
+		t.Fatalf("SaveAtomic: %v", err)
+	}
+	if err := SaveAtomic(path, []byte("v2"), nil); err != nil {
+		t.Fatalf("SaveAtomic second write: %v", err)
+	}
+
+	loaded, err := LoadMeta(path)
+	if err != nil {
+		t.Fatalf("LoadMeta: %v", err)
+	}
+	if !loaded.CheckPassword("secret") {
+		t.Fatalf("expected password to survive a content-only write")
+	}
+}
+
+func TestLoadMetaMissingReturnsNil(t *testing.T) {
+	dir := t.TempDir()
+	meta, err := LoadMeta(filepath.Join(dir, "missing"))
+	if err != nil {
+		t.Fatalf("LoadMeta: %v", err)
+	}
+	if meta != nil {
+		t.Fatalf("expected a missing sidecar to return nil meta, got %+v", meta)
+	}
+	if meta.HasPassword() {
+		t.Fatalf("expected a missing sidecar to report no password")
+	}
+}
+
+func TestMetaExpired(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	meta := &Meta{PasswordHash: "x", ExpiresAt: &past}
+	if !meta.Expired(time.Now()) {
+		t.Fatalf("expected meta with a past ExpiresAt to be expired")
+	}
+}
+
+func TestDeleteContentAndMetaRemovesBoth(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "note")
+	meta, _ := NewPasswordMeta("secret", "text/plain", nil)
+	if err := SaveAtomic(path, []byte("hello"), meta); err != nil {
+		t.Fatalf("SaveAtomic: %v", err)
+	}
+
+	if err := DeleteContentAndMeta(path); err != nil {
+		t.Fatalf("DeleteContentAndMeta: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected content file to be gone")
+	}
+	if _, err := os.Stat(metaPath(path)); !os.IsNotExist(err) {
+		t.Fatalf("expected sidecar file to be gone")
+	}
+}
+
+func TestUnlockCookieRoundTrip(t *testing.T) {
+	cookie := SignUnlockCookie("secret", "/notes/abc")
+	if !VerifyUnlockCookie("secret", "/notes/abc", cookie) {
+		t.Fatalf("expected a freshly signed cookie to verify")
+	}
+	if VerifyUnlockCookie("secret", "/notes/other", cookie) {
+		t.Fatalf("cookie signed for one path should not verify for another")
+	}
+	if VerifyUnlockCookie("wrong-secret", "/notes/abc", cookie) {
+		t.Fatalf("cookie should not verify under a different secret")
+	}
+}
@@ -0,0 +1,65 @@
+package notes
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// SaveAtomic 原子地写入笔记内容，并在 meta 非空时一并写入 sidecar 元数据。
+// 两个文件各自走"写临时文件 + rename"，避免进程在写一半时崩溃，让内容和
+// 密码保护的 ACL 处于不一致的状态（比如内容已经写完但密码哈希还没落盘）。
+// meta 为 nil 时表示这次调用不touch 已有的 ACL，只更新内容。
+func SaveAtomic(contentPath string, content []byte, meta *Meta) error {
+	dir := filepath.Dir(contentPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	// 先落 sidecar 再落内容：中途崩溃最多留下密码已生效、内容还是旧版本
+	// （或尚不存在）的笔记，不会出现内容已写完却还没有密码保护的状态
+	if meta != nil {
+		data, err := marshalMeta(meta)
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomic(metaPath(contentPath), data); err != nil {
+			return err
+		}
+	}
+
+	return writeFileAtomic(contentPath, content)
+}
+
+// DeleteContentAndMeta 删除笔记内容及其 sidecar 元数据（如果存在）
+func DeleteContentAndMeta(contentPath string) error {
+	if err := os.Remove(contentPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(metaPath(contentPath)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return nil
+}
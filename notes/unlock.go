@@ -0,0 +1,28 @@
+package notes
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// SignUnlockCookie 用配置的密钥对 path 做 HMAC 签名，作为 POST /:path/unlock
+// 成功后下发的 cookie 值。cookie 本身不携带密码，泄露也不会暴露原始密码
+func SignUnlockCookie(secret, path string) string {
+	return sign(secret, path)
+}
+
+// VerifyUnlockCookie 校验某个 cookie 值是否是给定 secret 对 path 的合法签名
+func VerifyUnlockCookie(secret, path, cookie string) bool {
+	if cookie == "" {
+		return false
+	}
+	expected := sign(secret, path)
+	return hmac.Equal([]byte(expected), []byte(cookie))
+}
+
+func sign(secret, path string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(path))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,76 @@
+// Package render 提供跨 handler 复用的内容协商逻辑：同一份数据可以按
+// Accept 头或 ?format= 覆盖，分别以 HTML、JSON、XML、JSONP 或 AsciiJSON
+// 的形式返回给客户端。
+package render
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HTML 描述了协商结果为网页时应当使用的模板名与渲染数据
+type HTML struct {
+	Name string
+	Data gin.H
+}
+
+// Negotiate 根据请求选择合适的渲染方式输出 payload。payload 应当是一个
+// 同时带有 json 与 xml 标签的结构体，这样才能在 XML 分支下被正确编码
+// （encoding/xml 无法序列化 map，因此这里不能像别处一样直接传 gin.H）。
+func Negotiate(c *gin.Context, status int, payload interface{}, html HTML) {
+	switch resolveFormat(c) {
+	case "html":
+		c.HTML(status, html.Name, html.Data)
+	case "xml":
+		c.XML(status, payload)
+	case "jsonp":
+		callback := c.Query("callback")
+		if callback == "" {
+			// 没有回调名就退化成普通 JSON，而不是拼出一个没意义的 JSONP
+			c.JSON(status, payload)
+			return
+		}
+		c.JSONP(status, payload)
+	default:
+		if c.Query("ascii") == "1" {
+			c.AsciiJSON(status, payload)
+			return
+		}
+		c.JSON(status, payload)
+	}
+}
+
+// resolveFormat 优先读取 ?format= 覆盖参数，其次按 Accept 头做简单匹配，
+// 默认落回 JSON
+func resolveFormat(c *gin.Context) string {
+	if format := c.Query("format"); format != "" {
+		return normalizeFormat(format)
+	}
+
+	accept := c.GetHeader("Accept")
+	switch {
+	case strings.Contains(accept, "text/html"):
+		return "html"
+	case strings.Contains(accept, "application/xml"), strings.Contains(accept, "text/xml"):
+		return "xml"
+	case strings.Contains(accept, "application/javascript"):
+		return "jsonp"
+	default:
+		return "json"
+	}
+}
+
+// normalizeFormat 把 ?format= 的自由文本值归一到 Negotiate 认识的几个关键字
+func normalizeFormat(format string) string {
+	switch strings.ToLower(format) {
+	case "html":
+		return "html"
+	case "xml":
+		return "xml"
+	case "jsonp":
+		return "jsonp"
+	default:
+		return "json"
+	}
+}
@@ -0,0 +1,103 @@
+package render
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type testPayload struct {
+	XMLName xml.Name `xml:"note" json:"-"`
+	Title   string   `xml:"title" json:"title"`
+	Body    string   `xml:"body" json:"body"`
+}
+
+func performNegotiate(t *testing.T, target string, accept string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.LoadHTMLGlob("testdata/*.html")
+	r.GET("/note", func(c *gin.Context) {
+		payload := testPayload{Title: "hello", Body: "world"}
+		Negotiate(c, http.StatusOK, payload, HTML{Name: "note.html", Data: gin.H{"title": payload.Title, "body": payload.Body}})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestNegotiateJSONDefault(t *testing.T) {
+	w := performNegotiate(t, "/note", "")
+	if ct := w.Header().Get("Content-Type"); !strings.Contains(ct, "application/json") {
+		t.Fatalf("expected json content type, got %q", ct)
+	}
+	if !strings.Contains(w.Body.String(), `"title":"hello"`) {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+}
+
+func TestNegotiateXMLViaAccept(t *testing.T) {
+	w := performNegotiate(t, "/note", "application/xml")
+	if ct := w.Header().Get("Content-Type"); !strings.Contains(ct, "application/xml") {
+		t.Fatalf("expected xml content type, got %q", ct)
+	}
+	if !strings.Contains(w.Body.String(), "<note>") {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+}
+
+func TestNegotiateFormatOverride(t *testing.T) {
+	w := performNegotiate(t, "/note?format=xml", "application/json")
+	if ct := w.Header().Get("Content-Type"); !strings.Contains(ct, "application/xml") {
+		t.Fatalf("?format= should win over Accept header, got %q", ct)
+	}
+}
+
+func TestNegotiateJSONP(t *testing.T) {
+	w := performNegotiate(t, "/note?format=jsonp&callback=cb", "")
+	if !strings.HasPrefix(w.Body.String(), "cb(") {
+		t.Fatalf("expected jsonp-wrapped body, got %s", w.Body.String())
+	}
+}
+
+func TestNegotiateJSONPWithoutCallbackFallsBackToJSON(t *testing.T) {
+	w := performNegotiate(t, "/note?format=jsonp", "")
+	if ct := w.Header().Get("Content-Type"); !strings.Contains(ct, "application/json") {
+		t.Fatalf("expected plain json content type without callback, got %q", ct)
+	}
+}
+
+func TestNegotiateAsciiJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/note", func(c *gin.Context) {
+		payload := testPayload{Title: "héllo", Body: "wörld"}
+		Negotiate(c, http.StatusOK, payload, HTML{Name: "note.html", Data: gin.H{}})
+	})
+	req := httptest.NewRequest(http.MethodGet, "/note?ascii=1", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if strings.ContainsAny(w.Body.String(), "éö") {
+		t.Fatalf("ascii json should escape non-ascii runes, got %s", w.Body.String())
+	}
+}
+
+func TestNegotiateHTML(t *testing.T) {
+	w := performNegotiate(t, "/note", "text/html")
+	if ct := w.Header().Get("Content-Type"); !strings.Contains(ct, "text/html") {
+		t.Fatalf("expected html content type, got %q", ct)
+	}
+	if !strings.Contains(w.Body.String(), "hello") {
+		t.Fatalf("expected rendered template to contain title, got %s", w.Body.String())
+	}
+}
@@ -0,0 +1,48 @@
+package render
+
+import (
+	"bytes"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/yuin/goldmark"
+)
+
+// Markdown 把笔记内容按 CommonMark 规则转换成 HTML 片段，供 markdown.html
+// 模板直接嵌入（调用方需要把结果转成 template.HTML，否则会被转义）
+func Markdown(source string) (string, error) {
+	var buf bytes.Buffer
+	if err := goldmark.Convert([]byte(source), &buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Highlight 用 Chroma 给笔记内容做语法高亮，lang 为空或未识别时退回到
+// 纯文本词法分析器，保证总能出结果而不是报错
+func Highlight(source, lang string) (string, error) {
+	lexer := lexers.Get(lang)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	lexer = chroma.Coalesce(lexer)
+
+	style := styles.Get("github")
+	if style == nil {
+		style = styles.Fallback
+	}
+
+	iterator, err := lexer.Tokenise(nil, source)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	formatter := chromahtml.New(chromahtml.WithClasses(true))
+	if err := formatter.Format(&buf, style, iterator); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,39 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdownRendersCommonMark(t *testing.T) {
+	htmlBody, err := Markdown("# Title\n\nSome *text*.")
+	if err != nil {
+		t.Fatalf("Markdown: %v", err)
+	}
+	if !strings.Contains(htmlBody, "<h1>Title</h1>") {
+		t.Fatalf("expected a rendered heading, got %s", htmlBody)
+	}
+	if !strings.Contains(htmlBody, "<em>text</em>") {
+		t.Fatalf("expected emphasis to render, got %s", htmlBody)
+	}
+}
+
+func TestHighlightKnownLanguage(t *testing.T) {
+	htmlBody, err := Highlight("package main\n", "go")
+	if err != nil {
+		t.Fatalf("Highlight: %v", err)
+	}
+	if !strings.Contains(htmlBody, "package") {
+		t.Fatalf("expected the source to show up in the highlighted output, got %s", htmlBody)
+	}
+}
+
+func TestHighlightUnknownLanguageFallsBack(t *testing.T) {
+	htmlBody, err := Highlight("just some text", "not-a-real-language")
+	if err != nil {
+		t.Fatalf("Highlight should fall back instead of erroring, got %v", err)
+	}
+	if !strings.Contains(htmlBody, "just some text") {
+		t.Fatalf("expected source to still appear, got %s", htmlBody)
+	}
+}
@@ -0,0 +1,115 @@
+// Package shortid 生成笔记路径用的短随机 ID：用 crypto/rand 取字节，
+// 通过拒绝采样映射到可配置的字母表以避免取模偏差，并在调用方提供的存储
+// 目录下做碰撞检测，撞库则重试（长度随重试次数指数增长）。
+package shortid
+
+import (
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// 内建的几种字母表。NoLookalike 去掉了容易认错的 0/O、1/l/I，便于人工
+// 誊抄分享出去的链接
+const (
+	Base58      = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	Base62      = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	NoLookalike = "23456789ABCDEFGHJKMNPQRSTUVWXYZabcdefghijkmnpqrstuvwxyz"
+)
+
+const (
+	defaultLength           = 8
+	defaultCollisionRetries = 5
+)
+
+// ErrExhausted 在重试耗尽仍然撞库时返回
+var ErrExhausted = errors.New("shortid: exhausted collision retries")
+
+// Config 描述如何生成一个 ID：字母表/长度来自 config.yml 的 random 小节，
+// Dir 由调用方传入，是做碰撞检测时要 os.Stat 的笔记存储目录（留空则跳过
+// 碰撞检测，方便单测）
+type Config struct {
+	Alphabet         string // "base58" | "base62" | "nolookalike"，默认 base62
+	Length           int    // 生成 ID 的字符数，默认 8
+	CollisionRetries int    // 撞库后最多重试几次，默认 5
+	Dir              string // 碰撞检测时 os.Stat 的目录
+}
+
+// New 按 cfg 生成一个 ID。目标目录下已经存在同名文件时视为撞库，重试；
+// 每次重试把长度再拉长一截，重试次数用尽后仍然撞库则返回 ErrExhausted
+func New(cfg Config) (string, error) {
+	alphabet := alphabetFor(cfg.Alphabet)
+
+	length := cfg.Length
+	if length <= 0 {
+		length = defaultLength
+	}
+	retries := cfg.CollisionRetries
+	if retries <= 0 {
+		retries = defaultCollisionRetries
+	}
+
+	for attempt := 0; attempt <= retries; attempt++ {
+		id, err := generate(alphabet, length)
+		if err != nil {
+			return "", err
+		}
+
+		if cfg.Dir == "" {
+			return id, nil
+		}
+		if _, err := os.Stat(filepath.Join(cfg.Dir, id)); os.IsNotExist(err) {
+			return id, nil
+		}
+
+		length *= 2 // 撞库了，下一轮拉长 ID 降低再次撞库的概率
+	}
+
+	return "", ErrExhausted
+}
+
+// alphabetFor 把配置里的别名解析成实际字母表，未识别的值回落到 base62
+func alphabetFor(name string) string {
+	switch name {
+	case "base58":
+		return Base58
+	case "nolookalike":
+		return NoLookalike
+	default:
+		return Base62
+	}
+}
+
+// generate 从 crypto/rand 里拒绝采样 length 个字母表内的字符，避免
+// byte % len(alphabet) 在 256 不能被 len(alphabet) 整除时产生的偏差
+func generate(alphabet string, length int) (string, error) {
+	n := len(alphabet)
+	if n == 0 || n > 256 {
+		return "", errors.New("shortid: alphabet must have between 1 and 256 symbols")
+	}
+	// 大于等于 limit 的字节会让低位分布不均，直接丢弃重采样。limit 用 int
+	// 算，256 个符号的字母表不需要拒绝任何字节（n==256 时 limit 就是 256），
+	// 这种情况下 byte(limit) 会回绕成 0，所以不能直接存成 byte
+	limit := 256 - 256%n
+
+	out := make([]byte, length)
+	scratch := make([]byte, length)
+	filled := 0
+	for filled < length {
+		if _, err := rand.Read(scratch); err != nil {
+			return "", err
+		}
+		for _, b := range scratch {
+			if int(b) >= limit {
+				continue
+			}
+			out[filled] = alphabet[int(b)%n]
+			filled++
+			if filled == length {
+				break
+			}
+		}
+	}
+	return string(out), nil
+}
@@ -0,0 +1,18 @@
+package shortid
+
+import "testing"
+
+// BenchmarkNewParallel 在并发下生成 ID（不带碰撞检测，Dir 留空），用
+// -benchmem 验证每次 New 调用只产生常数级别的分配，不会随并发度升高
+func BenchmarkNewParallel(b *testing.B) {
+	cfg := Config{Alphabet: "base62", Length: 10}
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := New(cfg); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package shortid
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewUsesConfiguredLength(t *testing.T) {
+	id, err := New(Config{Length: 12})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if len(id) != 12 {
+		t.Fatalf("expected a 12-char id, got %q (%d)", id, len(id))
+	}
+}
+
+func TestNewDefaultsLengthWhenUnset(t *testing.T) {
+	id, err := New(Config{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if len(id) != defaultLength {
+		t.Fatalf("expected default length %d, got %d", defaultLength, len(id))
+	}
+}
+
+func TestNewNoLookalikeAvoidsConfusingChars(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		id, err := New(Config{Alphabet: "nolookalike", Length: 16})
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		if strings.ContainsAny(id, "0O1lI") {
+			t.Fatalf("nolookalike id contains a confusing character: %q", id)
+		}
+	}
+}
+
+func TestNewRetriesOnCollision(t *testing.T) {
+	dir := t.TempDir()
+
+	// 占用第一个可能生成的 ID 不现实，改为先跑一次拿到真实会生成的 ID，
+	// 在目录里把它占位，再验证第二次调用能绕开它
+	taken, err := New(Config{Alphabet: "base58", Length: 4, Dir: dir})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, taken), []byte("x"), 0644); err != nil {
+		t.Fatalf("seed collision file: %v", err)
+	}
+
+	id, err := New(Config{Alphabet: "base58", Length: 4, Dir: dir, CollisionRetries: 3})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if id == taken {
+		t.Fatalf("expected New to avoid the pre-existing file %q", taken)
+	}
+	if _, err := os.Stat(filepath.Join(dir, id)); !os.IsNotExist(err) {
+		t.Fatalf("returned id %q unexpectedly already exists on disk", id)
+	}
+}
+
+func TestGenerateRejectsEmptyAlphabet(t *testing.T) {
+	if _, err := generate("", 4); err == nil {
+		t.Fatalf("expected an error for an empty alphabet")
+	}
+}
+
+func TestGenerateFullByteAlphabetDoesNotHang(t *testing.T) {
+	alphabet := make([]byte, 256)
+	for i := range alphabet {
+		alphabet[i] = byte(i)
+	}
+	if _, err := generate(string(alphabet), 8); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+}